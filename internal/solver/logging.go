@@ -0,0 +1,29 @@
+package solver
+
+import (
+	"os"
+
+	"github.com/grussorusso/serverledge/internal/config"
+
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Logger is the package-level structured logger threaded through Run, solve,
+// watchAllocation, computeFunctionsAllocation and the etcd helpers, so
+// cluster-wide debugging can grep or ship per-field events instead of
+// parsing free-form messages. Its level is controlled by the LOG_LEVEL
+// config key (debug|info|warn|error|crit), parsed once at startup.
+var Logger = newLogger()
+
+func newLogger() log15.Logger {
+	logger := log15.New("pkg", "solver")
+
+	level, err := log15.LvlFromString(config.GetString(config.LOG_LEVEL, "info"))
+	if err != nil {
+		level = log15.LvlInfo
+	}
+
+	logger.SetHandler(log15.LvlFilterHandler(level, log15.StreamHandler(os.Stderr, log15.LogfmtFormat())))
+
+	return logger
+}
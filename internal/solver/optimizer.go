@@ -0,0 +1,63 @@
+package solver
+
+import (
+	"fmt"
+
+	"github.com/grussorusso/serverledge/internal/config"
+)
+
+// defaultSolverBackend is used when the SOLVER_BACKEND config key is unset
+// and more than one backend is registered. It names the existing
+// PythonOptimizer so upgrading a deployment doesn't silently switch it onto
+// the pure-Go MilpOptimizer's greedy heuristic, which has no optimality
+// guarantee and hasn't been benchmarked against the ILP. Set
+// SOLVER_BACKEND=milp to opt into it explicitly. A build without the
+// cgo_python tag has no "python" entry to fall back on, so in that case
+// newOptimizer instead falls back to whichever single backend was actually
+// registered.
+const defaultSolverBackend = "python"
+
+// Optimizer computes a function-to-node allocation that minimizes cluster
+// energy consumption given the current node and function information.
+// Implementations register themselves with registerOptimizer, typically from
+// an init() function gated behind a build tag, so the binary only pulls in
+// the dependencies of the backends it was built with.
+type Optimizer interface {
+	Solve(NodeInformation, FunctionInformation) (SolverResults, error)
+}
+
+var optimizers = make(map[string]Optimizer)
+
+// registerOptimizer makes an Optimizer available for selection via the
+// SOLVER_BACKEND config key.
+func registerOptimizer(name string, optimizer Optimizer) {
+	optimizers[name] = optimizer
+}
+
+// newOptimizer returns the Optimizer selected by the SOLVER_BACKEND config
+// key, enabling computeFunctionsAllocation to be unit-tested (and this
+// binary to be cross-compiled) without embedding a Python interpreter.
+func newOptimizer() (Optimizer, error) {
+	configuredBackend := config.GetString(config.SOLVER_BACKEND, "")
+
+	backend := configuredBackend
+	if backend == "" {
+		backend = defaultSolverBackend
+	}
+
+	if optimizer, ok := optimizers[backend]; ok {
+		return optimizer, nil
+	}
+
+	// SOLVER_BACKEND was left unset and defaultSolverBackend isn't registered
+	// in this build (e.g. one built without the cgo_python tag). Fall back to
+	// the sole registered backend rather than failing every epoch, as long as
+	// there's no ambiguity about which one that is.
+	if configuredBackend == "" && len(optimizers) == 1 {
+		for _, optimizer := range optimizers {
+			return optimizer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unknown solver backend %q (was it registered by this build?)", backend)
+}
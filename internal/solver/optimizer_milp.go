@@ -0,0 +1,125 @@
+package solver
+
+import (
+	"sort"
+	"time"
+)
+
+func init() {
+	registerOptimizer("milp", &MilpOptimizer{})
+}
+
+// MilpOptimizer addresses the same energy-minimization problem as
+// PythonOptimizer natively in Go, with no cgo or Python runtime dependency.
+// It does not build or solve an ILP: it uses a greedy first-fit heuristic,
+// not a branch-and-bound search, and gives no optimality guarantee: it has
+// not been benchmarked against the ILP, so it is opt-in via
+// SOLVER_BACKEND=milp rather than the default. It is, however, enough to
+// unit-test computeFunctionsAllocation without spinning up a Python
+// interpreter.
+//
+// A future revision could swap this for an exact MILP solve (e.g. via
+// github.com/draffensperger/golp's GLPK bindings) behind its own build tag,
+// selected through the same SOLVER_BACKEND mechanism.
+type MilpOptimizer struct{}
+
+func (o *MilpOptimizer) Solve(nodeInfo NodeInformation, functionInfo FunctionInformation) (SolverResults, error) {
+	start := time.Now()
+
+	numberOfNodes := len(nodeInfo.TotalMemoryMB)
+	numberOfFunctions := len(functionInfo.MemoryMB)
+
+	remainingCapacity := make([]int, numberOfNodes)
+	copy(remainingCapacity, nodeInfo.MaximumCapacity)
+
+	nodesInstances := make(map[int][]interface{}, numberOfNodes)
+	for n := 0; n < numberOfNodes; n++ {
+		nodesInstances[n] = make([]interface{}, numberOfFunctions)
+		for f := 0; f < numberOfFunctions; f++ {
+			nodesInstances[n][f] = float64(0)
+		}
+	}
+
+	functionsCapacity := make([]float64, numberOfFunctions)
+	activeNodes := make(map[int]bool)
+
+	// Functions with the tightest deadline are placed first. Each one is
+	// packed onto nodes in order of increasing power consumption, taking
+	// whatever spare capacity a node has and spilling the rest of its
+	// workload onto the next node (a first-fit bin-packing strategy).
+	order := make([]int, numberOfFunctions)
+	for f := range order {
+		order[f] = f
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return functionInfo.Deadline[order[a]] < functionInfo.Deadline[order[b]]
+	})
+
+	nodesByPower := make([]int, numberOfNodes)
+	for n := range nodesByPower {
+		nodesByPower[n] = n
+	}
+	sort.Slice(nodesByPower, func(a, b int) bool {
+		return nodeInfo.PowerConsumption[nodesByPower[a]] < nodeInfo.PowerConsumption[nodesByPower[b]]
+	})
+
+	for _, f := range order {
+		required := functionInfo.Workload[f]
+		if required <= 0 {
+			continue
+		}
+
+		for _, n := range nodesByPower {
+			if remainingCapacity[n] <= 0 {
+				continue
+			}
+
+			ipc := nodeInfo.IPC[n]
+			if ipc <= 0 {
+				ipc = 1
+			}
+
+			capacityPerInstance := ipc
+			if capacityPerInstance > remainingCapacity[n] {
+				capacityPerInstance = remainingCapacity[n]
+			}
+
+			instances := (required + capacityPerInstance - 1) / capacityPerInstance
+			used := instances * capacityPerInstance
+			if used > remainingCapacity[n] {
+				instances = remainingCapacity[n] / capacityPerInstance
+				used = instances * capacityPerInstance
+			}
+			if instances <= 0 {
+				continue
+			}
+
+			nodesInstances[n][f] = float64(instances)
+			functionsCapacity[f] += float64(used)
+			remainingCapacity[n] -= used
+			activeNodes[n] = true
+
+			required -= used
+			if required <= 0 {
+				break
+			}
+		}
+	}
+
+	activeNodesIndexes := make([]int, 0, len(activeNodes))
+	var objective float64
+	for n := range activeNodes {
+		activeNodesIndexes = append(activeNodesIndexes, n)
+		objective += float64(nodeInfo.PowerConsumption[n])
+	}
+	sort.Ints(activeNodesIndexes)
+
+	return SolverResults{
+		SolverWalltime:     time.Since(start).Seconds(),
+		SolverStatusName:   "HEURISTIC_FEASIBLE",
+		ObjectiveValue:     objective,
+		ActiveNodesIndexes: activeNodesIndexes,
+		FunctionsCapacity:  functionsCapacity,
+		NodesInstances:     nodesInstances,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package solver
+
+import "testing"
+
+func TestMilpOptimizerSolve(t *testing.T) {
+	nodeInfo := NodeInformation{
+		TotalMemoryMB:         []int{4096, 4096},
+		ComputationalCapacity: []int{2000, 2000},
+		MaximumCapacity:       []int{1000, 1000},
+		IPC:                   []int{2, 2},
+		PowerConsumption:      []int{100, 300},
+	}
+	functionInfo := FunctionInformation{
+		MemoryMB:    []int{256},
+		Workload:    []int{500},
+		Deadline:    []int{1000},
+		Invocations: []int{10},
+	}
+
+	optimizer := &MilpOptimizer{}
+	results, err := optimizer.Solve(nodeInfo, functionInfo)
+	if err != nil {
+		t.Fatalf("Solve returned an error: %v", err)
+	}
+
+	if len(results.ActiveNodesIndexes) == 0 {
+		t.Fatalf("expected at least one active node, got none")
+	}
+
+	// The cheaper node (index 0, 100W) has enough capacity on its own and
+	// should be preferred over the pricier one (index 1, 300W).
+	if len(results.ActiveNodesIndexes) != 1 || results.ActiveNodesIndexes[0] != 0 {
+		t.Errorf("expected only the cheaper node 0 to be active, got %v", results.ActiveNodesIndexes)
+	}
+
+	if results.FunctionsCapacity[0] < float64(functionInfo.Workload[0]) {
+		t.Errorf("expected assigned capacity to cover the function's workload, got %v", results.FunctionsCapacity[0])
+	}
+
+	instances, ok := results.NodesInstances[0][0].(float64)
+	if !ok || instances <= 0 {
+		t.Errorf("expected node 0 to have a positive instance count for function 0, got %v", results.NodesInstances[0][0])
+	}
+}
+
+func TestMilpOptimizerSolveSkipsIdleFunctions(t *testing.T) {
+	nodeInfo := NodeInformation{
+		TotalMemoryMB:         []int{4096},
+		ComputationalCapacity: []int{2000},
+		MaximumCapacity:       []int{1000},
+		IPC:                   []int{2},
+		PowerConsumption:      []int{100},
+	}
+	functionInfo := FunctionInformation{
+		MemoryMB:    []int{256},
+		Workload:    []int{0},
+		Deadline:    []int{1000},
+		Invocations: []int{0},
+	}
+
+	optimizer := &MilpOptimizer{}
+	results, err := optimizer.Solve(nodeInfo, functionInfo)
+	if err != nil {
+		t.Fatalf("Solve returned an error: %v", err)
+	}
+
+	if len(results.ActiveNodesIndexes) != 0 {
+		t.Errorf("expected no active nodes for a function with zero workload, got %v", results.ActiveNodesIndexes)
+	}
+}
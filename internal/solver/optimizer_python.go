@@ -0,0 +1,95 @@
+//go:build cgo_python
+
+package solver
+
+/*
+#cgo CFLAGS: -I/usr/include/python3.10
+#cgo LDFLAGS: -lpython3.10
+#include <Python.h>
+#include <stdlib.h>
+
+extern void initializePython();
+extern void finalizePython();
+extern int* allocateMemory(int size);
+extern void freeMemory(int* arr);
+extern const char* startSolver(int numberOfNodes, int numberOfFunctions, int* nodeMemory, int* nodeCapacity, int* maximumCapacity, int* nodeIpc, int* nodePowerConsumption, int* functionMemory, int* functionWorkload, int* functionDeadline, int* functionInvocations);
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+)
+
+func init() {
+	registerOptimizer("python", &PythonOptimizer{})
+}
+
+// PythonOptimizer dispatches the energy-minimization ILP to the existing
+// CPython-embedded solver via cgo. It requires every Serverledge node in the
+// cluster to ship a matching Python runtime, and is only built when the
+// cgo_python build tag is set.
+type PythonOptimizer struct{}
+
+func (o *PythonOptimizer) Solve(nodeInfo NodeInformation, functionInfo FunctionInformation) (SolverResults, error) {
+	numberOfNodes := len(nodeInfo.TotalMemoryMB)
+	numberOfFunctions := len(functionInfo.MemoryMB)
+
+	C.initializePython()
+	//defer C.finalizePython()
+
+	cNodeInfo := allocateAndInitialize(nodeInfo.TotalMemoryMB)
+	defer C.freeMemory(cNodeInfo)
+	cComputationalCapacity := allocateAndInitialize(nodeInfo.ComputationalCapacity)
+	defer C.freeMemory(cComputationalCapacity)
+	cMaximumCapacity := allocateAndInitialize(nodeInfo.MaximumCapacity)
+	defer C.freeMemory(cMaximumCapacity)
+	cIPC := allocateAndInitialize(nodeInfo.IPC)
+	defer C.freeMemory(cIPC)
+	cPowerConsumption := allocateAndInitialize(nodeInfo.PowerConsumption)
+	defer C.freeMemory(cPowerConsumption)
+
+	cFunctionMemory := allocateAndInitialize(functionInfo.MemoryMB)
+	defer C.freeMemory(cFunctionMemory)
+	cFunctionWorkload := allocateAndInitialize(functionInfo.Workload)
+	defer C.freeMemory(cFunctionWorkload)
+	cFunctionDeadline := allocateAndInitialize(functionInfo.Deadline)
+	defer C.freeMemory(cFunctionDeadline)
+	cFunctionInvocations := allocateAndInitialize(functionInfo.Invocations)
+	defer C.freeMemory(cFunctionInvocations)
+
+	cResults := C.startSolver(
+		C.int(numberOfNodes),
+		C.int(numberOfFunctions),
+		cNodeInfo,
+		cComputationalCapacity,
+		cMaximumCapacity,
+		cIPC,
+		cPowerConsumption,
+		cFunctionMemory,
+		cFunctionWorkload,
+		cFunctionDeadline,
+		cFunctionInvocations,
+	)
+
+	jsonStr := C.GoString(cResults)
+
+	var results SolverResults
+	if err := json.Unmarshal([]byte(jsonStr), &results); err != nil {
+		return SolverResults{}, fmt.Errorf("Error unmarshalling results: %v", err)
+	}
+
+	return results, nil
+}
+
+// allocateAndInitialize copies a Go int slice into freshly allocated C
+// memory, for handoff across the cgo boundary.
+func allocateAndInitialize(data []int) *C.int {
+	size := len(data)
+	cArray := C.allocateMemory(C.int(size))
+	for i := 0; i < size; i++ {
+		cElement := (*C.int)(unsafe.Pointer(uintptr(unsafe.Pointer(cArray)) + uintptr(i)*unsafe.Sizeof(*cArray)))
+		*cElement = C.int(data[i])
+	}
+	return cArray
+}
@@ -1,18 +1,5 @@
 package solver
 
-/*
-#cgo CFLAGS: -I/usr/include/python3.10
-#cgo LDFLAGS: -lpython3.10
-#include <Python.h>
-#include <stdlib.h>
-
-extern void initializePython();
-extern void finalizePython();
-extern int* allocateMemory(int size);
-extern void freeMemory(int* arr);
-extern const char* startSolver(int numberOfNodes, int numberOfFunctions, int* nodeMemory, int* nodeCapacity, int* maximumCapacity, int* nodeIpc, int* nodePowerConsumption, int* functionMemory, int* functionWorkload, int* functionDeadline, int* functionInvocations);
-*/
-import "C"
 import (
 	"encoding/json"
 	"log"
@@ -20,7 +7,7 @@ import (
 	"fmt"
 	"math"
 	"errors"
-	"unsafe"
+	"sync"
 
 	"github.com/grussorusso/serverledge/internal/config"
 	"github.com/grussorusso/serverledge/internal/registration"
@@ -32,156 +19,393 @@ import (
 	"github.com/shirou/gopsutil/mem"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
 	"golang.org/x/net/context"
 )
 
+// Key under which the current solver leader publishes its identity, and the
+// prefix used for the underlying etcd election.
+const (
+	leaderKey      = "solver/leader"
+	electionPrefix = "solver/election"
+)
+
+// lastAllocationPayload caches the last allocation this node successfully
+// computed, regardless of whether the allocation store (see store.go's
+// currentAllocationStore) managed to persist it remotely.
+var lastAllocationPayload = NewInMemStore()
+
+// shutdownCh is closed by Shutdown to cancel the running candidate/watcher
+// loop cleanly, instead of leaving them blocked forever.
+var (
+	shutdownCh   = make(chan struct{})
+	shutdownOnce sync.Once
+)
+
+// Shutdown cancels any in-flight election campaign or allocation watch, so
+// Run returns instead of blocking forever. Safe to call more than once.
+func Shutdown() {
+	shutdownOnce.Do(func() {
+		close(shutdownCh)
+	})
+}
+
 func Run() {
-	err := initNodeResources()
-	if err != nil {
+	// A failure to read local node resources at startup is unrecoverable:
+	// there is nothing useful this node can report or run without it.
+	if err := initNodeResources(); err != nil {
 		log.Fatalf("Error in initializing node resources: %v", err)
 		return
 	}
 
-	isSolverNode := config.GetBool(config.IS_SOLVER_NODE, false)
+	setAllocationStore(newAllocationStore())
+
+	// Seed Allocation from the store so a restarted node isn't blind until
+	// the next epoch.
+	if payload, err := currentAllocationStore().Load(); err != nil {
+		Logger.Info("No persisted allocation to restore", "err", err)
+	} else {
+		var allocation FunctionsAllocation
+		if err := json.Unmarshal(payload, &allocation); err != nil {
+			Logger.Error("Error unmarshalling persisted allocation", "err", err)
+		} else {
+			setAllocation(allocation)
+			Logger.Info("Restored allocation", "allocation", allocation)
+		}
+	}
+
+	isCandidate := config.GetBool(config.IS_SOLVER_NODE, false)
+	if !isCandidate {
+		watchAllocation()
+		return
+	}
+
+	runCandidate()
+}
+
+// runCandidate makes this node compete for solver leadership. Any number of
+// nodes may run as candidates: only the elected leader ticks the epoch and
+// calls solve(), while the others fall back to watchAllocation() until they
+// either win the election or the leader steps down.
+func runCandidate() {
+	epochDuration := time.Duration(config.GetInt(config.EPOCH_DURATION, 30)) * time.Second
+	nodeId := utils.GetIpAddress().String()
+
+	for {
+		select {
+		case <-shutdownCh:
+			return
+		default:
+		}
+
+		etcdClient, err := utils.GetEtcdClient()
+		if err != nil {
+			Logger.Error("Error getting etcd client", "err", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		// The election lease is tied to the epoch duration: a leader that
+		// misses an epoch (e.g. because it died) loses leadership in time
+		// for a follower to take over before the next one is due.
+		session, err := concurrency.NewSession(etcdClient, concurrency.WithTTL(int(epochDuration.Seconds())))
+		if err != nil {
+			Logger.Error("Error creating etcd session", "err", err)
+			time.Sleep(time.Second)
+			continue
+		}
 
-	if isSolverNode {
-		epochDuration := config.GetInt(config.EPOCH_DURATION, 30)
-		solverTicker := time.NewTicker(time.Duration(epochDuration) * time.Second) // TODO: time.Minute
-		defer solverTicker.Stop()
+		// While campaigning, behave like a follower so the node still has a
+		// usable Allocation if it never wins.
+		watchStopCh := make(chan struct{})
+		watchDoneCh := make(chan struct{})
+		go func() {
+			defer close(watchDoneCh)
+			watchAllocationUntil(watchStopCh)
+		}()
+
+		campaignCtx, cancelCampaign := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-shutdownCh:
+				cancelCampaign()
+			case <-campaignCtx.Done():
+			}
+		}()
+
+		election := concurrency.NewElection(session, electionPrefix)
+		campaignErr := election.Campaign(campaignCtx, nodeId)
+		close(watchStopCh)
+		<-watchDoneCh
+		cancelCampaign()
+
+		if campaignErr != nil {
+			Logger.Error("Error campaigning for solver leadership", "err", campaignErr)
+			session.Close()
 
-		for {
 			select {
-			case <-solverTicker.C:
-				solve()
+			case <-shutdownCh:
+				return
+			default:
 			}
+			time.Sleep(time.Second)
+			continue
 		}
-	} else {
-		watchAllocation()
+
+		Logger.Info("Elected as solver leader", "node", nodeId)
+		if err := publishLeader(session, election, nodeId); err != nil {
+			Logger.Error("Error publishing solver leader", "err", err)
+		}
+
+		// Trigger a solve cycle immediately rather than waiting a full epoch.
+		if err := solve(); err != nil {
+			Logger.Error("Epoch failed", "err", err)
+		}
+		leadUntilDeposed(session, election, epochDuration)
+
+		session.Close()
+		Logger.Info("Lost solver leadership, switching to watcher mode", "node", nodeId)
 	}
 }
 
-func watchAllocation() {
-	log.Println("Running watcher for allocation")
+// leadUntilDeposed runs the periodic solve() loop for as long as this node
+// remains the leader, returning once the etcd session backing the election
+// is closed (e.g. the lease expired or etcd became unreachable), or once
+// Shutdown is called.
+func leadUntilDeposed(session *concurrency.Session, election *concurrency.Election, epochDuration time.Duration) {
+	solverTicker := time.NewTicker(epochDuration)
+	defer solverTicker.Stop()
+
+	for {
+		select {
+		case <-solverTicker.C:
+			if err := solve(); err != nil {
+				Logger.Error("Epoch failed", "err", err)
+			}
+		case <-session.Done():
+			return
+		case <-shutdownCh:
+			return
+		}
+	}
+}
+
+// publishLeader surfaces the current leader's identity under leaderKey so
+// operators can inspect cluster state directly, independently of the
+// election package's own internal bookkeeping keys.
+func publishLeader(session *concurrency.Session, election *concurrency.Election, nodeId string) error {
+	etcdClient := session.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := etcdClient.Put(ctx, leaderKey, nodeId, clientv3.WithLease(session.Lease()))
+	return err
+}
+
+// GetLeader returns the identity of the current solver leader, as last
+// published under leaderKey.
+func GetLeader() (string, error) {
 	etcdClient, err := utils.GetEtcdClient()
 	if err != nil {
-		log.Fatal(err)
-		return
+		return "", err
 	}
 
-    watchChan := etcdClient.Watch(context.Background(), "allocation")
-    for watchResp := range watchChan {
-        for _, event := range watchResp.Events {
-            log.Printf("Event received! Type: %s Key: %s Value: %s\n", event.Type, event.Kv.Key, event.Kv.Value)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-			// Update functions allocation
-			allocation, err := getAllocationFromEtcd()
-			if err != nil {
-				log.Printf("Error retrieving allocation: %v\n", err)
-				continue
+	resp, err := etcdClient.Get(ctx, leaderKey)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get solver leader: %v", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return "", fmt.Errorf("No solver leader found")
+	}
+
+	return string(resp.Kvs[0].Value), nil
+}
+
+// watchReconnectBaseDelay is the initial backoff between reconnect attempts
+// after the allocation watch channel closes or etcd becomes unreachable; it
+// doubles on every further failure, capped at watchReconnectMaxDelay.
+const (
+	watchReconnectBaseDelay = 1 * time.Second
+	watchReconnectMaxDelay  = 30 * time.Second
+)
+
+func watchAllocation() {
+	watchAllocationUntil(shutdownCh)
+}
+
+// watchAllocationUntil runs the allocation watch loop until stopCh is
+// closed, or forever if stopCh is nil. If the watch connection drops — the
+// channel closes, or etcd is unreachable — it reconnects with exponential
+// backoff rather than leaving the node stuck with a stale Allocation.
+func watchAllocationUntil(stopCh <-chan struct{}) {
+	Logger.Info("Running watcher for allocation")
+
+	delay := watchReconnectBaseDelay
+	for {
+		if stopped := runAllocationWatch(stopCh); stopped {
+			return
+		}
+
+		Logger.Warn("Allocation watch disconnected, reconnecting", "delay", delay)
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > watchReconnectMaxDelay {
+			delay = watchReconnectMaxDelay
+		}
+	}
+}
+
+// runAllocationWatch connects to etcd, re-syncs Allocation (in case events
+// were missed while disconnected), then watches "allocation" until the watch
+// channel closes or stopCh is closed. It returns true only if stopCh is what
+// ended the watch.
+func runAllocationWatch(stopCh <-chan struct{}) bool {
+	etcdClient, err := utils.GetEtcdClient()
+	if err != nil {
+		Logger.Warn("Error getting etcd client for allocation watch", "err", err)
+		return false
+	}
+
+	resyncAllocation()
+
+	// Cancelled on every exit path below, so the underlying watch stream is
+	// always torn down instead of leaking — this runs once per reconnect
+	// backoff cycle, and once per election campaign in runCandidate.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watchChan := etcdClient.Watch(ctx, "allocation")
+	for {
+		select {
+		case <-stopCh:
+			return true
+		case watchResp, ok := <-watchChan:
+			if !ok {
+				return false
+			}
+			for _, event := range watchResp.Events {
+				Logger.Debug("Event received", "type", event.Type, "key", string(event.Kv.Key), "value", string(event.Kv.Value))
+				resyncAllocation()
 			}
+		}
+	}
+}
 
-			setAllocation(allocation)
-			log.Printf("Updated Allocation: %v\n", Allocation)
-        }
-    }
+// resyncAllocation reloads Allocation from the store. If the allocation key
+// is missing — e.g. the leader died and its lease expired before a new
+// leader took over — this logs a warning and keeps the last-known Allocation
+// in place, rather than treating the empty response as "no functions
+// allocated".
+func resyncAllocation() {
+	payload, err := currentAllocationStore().Load()
+	if err != nil {
+		Logger.Warn("No allocation available, keeping last-known allocation", "err", err)
+		return
+	}
+
+	var allocation FunctionsAllocation
+	if err := json.Unmarshal(payload, &allocation); err != nil {
+		Logger.Error("Error unmarshalling allocation", "err", err)
+		return
+	}
+
+	setAllocation(allocation)
+	Logger.Info("Updated allocation", "allocation", Allocation)
 }
 
-func solve() {
-	log.Println("Running solver")
-	
+func solve() error {
+	Logger.Info("epoch_started")
+
 	// Get all available servers and functions
 	serversMap := registration.GetServersMap()
 	functions, err := function.GetAll()
 	if err != nil {
-		log.Fatalf("Error retrieving functions: %v", err)
-		return
+		return fmt.Errorf("Error retrieving functions: %v", err)
 	}
 
 	var numberOfNodes int = len(serversMap) + 1
 	var numberOfFunctions int = len(functions)
 
 	if numberOfNodes == 0 || numberOfFunctions == 0 {
-		return
+		return nil
 	}
 
+	// Scrape measured per-function resource usage from every peer so the
+	// solver works off real invocation/deadline-miss counts rather than the
+	// stale f.Invocations counter.
+	perNodeStats, clusterStats := scrapeClusterStats(serversMap)
+
 	// Prepare data slices
 	nodeInfo, nodeIp := prepareNodeInfo(serversMap)
-	functionInfo := prepareFunctionInfo(functions)
-
-	// Initialize Python interpreter
-	C.initializePython()
-	//defer C.finalizePython()
-
-	// Allocate and initialize memory for C arrays
-	cNodeInfo := allocateAndInitialize(nodeInfo.TotalMemoryMB)
-	defer C.freeMemory(cNodeInfo)
-	cComputationalCapacity := allocateAndInitialize(nodeInfo.ComputationalCapacity)
-	defer C.freeMemory(cComputationalCapacity)
-	cMaximumCapacity := allocateAndInitialize(nodeInfo.MaximumCapacity)
-	defer C.freeMemory(cMaximumCapacity)
-	cIPC := allocateAndInitialize(nodeInfo.IPC)
-	defer C.freeMemory(cIPC)
-	cPowerConsumption := allocateAndInitialize(nodeInfo.PowerConsumption)
-	defer C.freeMemory(cPowerConsumption)
-
-	cFunctionMemory := allocateAndInitialize(functionInfo.MemoryMB)
-	defer C.freeMemory(cFunctionMemory)
-	cFunctionWorkload := allocateAndInitialize(functionInfo.Workload)
-	defer C.freeMemory(cFunctionWorkload)
-	cFunctionDeadline := allocateAndInitialize(functionInfo.Deadline)
-	defer C.freeMemory(cFunctionDeadline)
-	cFunctionInvocations := allocateAndInitialize(functionInfo.Invocations)
-	defer C.freeMemory(cFunctionInvocations)
-
-	cResults := C.startSolver(
-		C.int(numberOfNodes),
-		C.int(numberOfFunctions),
-		cNodeInfo,
-		cComputationalCapacity,
-		cMaximumCapacity,
-		cIPC,
-		cPowerConsumption,
-		cFunctionMemory,
-		cFunctionWorkload,
-		cFunctionDeadline,
-		cFunctionInvocations,
-	)
-
-	// Process solver results
-	jsonStr := C.GoString(cResults)
+	functionInfo := prepareFunctionInfo(functions, clusterStats)
 
-	var results SolverResults
-	if err := json.Unmarshal([]byte(jsonStr), &results); err != nil {
-		log.Fatalf("Error unmarshalling results: %v", err)
-		return
+	optimizer, err := newOptimizer()
+	if err != nil {
+		return fmt.Errorf("Error selecting solver backend: %v", err)
 	}
 
-	// Log results
-	log.Printf("Solver walltime: %f", results.SolverWalltime)
-	log.Printf("Solver status: %s", results.SolverStatusName)
-	log.Printf("Energy consumption: %f", results.ObjectiveValue)
-	log.Printf("Active nodes: %v", results.ActiveNodesIndexes)
-	log.Printf("Functions capacity: %v", results.FunctionsCapacity)
+	results, err := optimizer.Solve(nodeInfo, functionInfo)
+	if err != nil {
+		return fmt.Errorf("Error solving allocation: %v", err)
+	}
 
+	Logger.Info("solver_finished",
+		"walltime", results.SolverWalltime,
+		"objective", results.ObjectiveValue,
+		"status", results.SolverStatusName,
+		"activeNodes", results.ActiveNodesIndexes,
+	)
+	Logger.Debug("Functions capacity", "capacity", results.FunctionsCapacity)
 	for nodeID, instances := range results.NodesInstances {
-		log.Printf("Node %d has instances: %v", nodeID, instances)
+		Logger.Debug("Node instances", "node", nodeID, "instances", instances)
 	}
-
-	log.Printf("Node IP addresses: %v", nodeIp)
+	Logger.Debug("Node IP addresses", "ips", nodeIp)
 
 	// Retrive functions allocation
 	allocation, err := computeFunctionsAllocation(results, functions, nodeIp)
 	if err != nil {
-		log.Fatalf("Error processing allocation: %v", err)
-		return
+		return fmt.Errorf("Error processing allocation: %v", err)
 	}
 
-	// Save allocation to Etcd
-	if err := saveAllocationToEtcd(allocation); err != nil {
-		log.Fatalf("Error saving allocation to Etcd: %v", err)
+	// Feed this epoch's measured usage back into the per (node, function) IPC
+	// and workload EWMA so future epochs' IPC estimates adapt instead of
+	// staying hard-coded.
+	for nodeIp, usage := range perNodeStats {
+		for functionName, functionUsage := range usage {
+			if functionUsage.CPUSeconds <= 0 {
+				continue
+			}
+
+			measuredIpc := float64(functionUsage.Invocations) / functionUsage.CPUSeconds
+			measuredWorkload := float64(functionUsage.Invocations)
+			updateIpcEwma(nodeIp, functionName, measuredIpc, measuredWorkload)
+		}
+	}
+
+	// Persist the allocation. Even if the store is a remote EtcdStore and the
+	// write fails, keep the last successful allocation around in-memory so a
+	// transient etcd outage doesn't blind this node.
+	payload, err := json.Marshal(allocation)
+	if err != nil {
+		Logger.Error("Error marshalling allocation", "err", err)
+	} else if err := currentAllocationStore().Save(payload); err != nil {
+		Logger.Error("Error saving allocation", "err", err)
+		lastAllocationPayload.Save(payload)
+		go retrySaveAllocation(payload)
+	} else {
+		lastAllocationPayload.Save(payload)
+		Logger.Info("allocation_published")
 	}
 
-	log.Println("Solver terminated")
+	return nil
 }
 
 func prepareNodeInfo(serversMap map[string]*registration.StatusInformation) (NodeInformation, []string) {
@@ -207,18 +431,25 @@ func prepareNodeInfo(serversMap map[string]*registration.StatusInformation) (Nod
 		i++
     }
 
+	selfIp := utils.GetIpAddress().String()
+
+	// Re-read the IPC EWMA every epoch rather than only once at startup in
+	// initNodeResources, so the solver actually sees the adaptive estimate
+	// as it's updated by solve()'s own feedback loop.
+	node.Resources.IPC = estimateNodeIPC(selfIp)
+
     nodeInfo.TotalMemoryMB[i] = int(node.Resources.TotalMemoryMB)
     nodeInfo.ComputationalCapacity[i] = int(node.Resources.ComputationalCapacity)
     nodeInfo.MaximumCapacity[i] = int(node.Resources.MaximumCapacity)
     nodeInfo.IPC[i] = int(node.Resources.IPC * 10)
     nodeInfo.PowerConsumption[i] = int(node.Resources.PowerConsumption)
 
-	nodeIp[i] = utils.GetIpAddress().String()
+	nodeIp[i] = selfIp
 
 	return nodeInfo, nodeIp
 }
 
-func prepareFunctionInfo(functions []string) FunctionInformation {
+func prepareFunctionInfo(functions []string, clusterStats map[string]TaskResourceUsage) FunctionInformation {
 	functionInfo := FunctionInformation{
 		MemoryMB:		make([]int, len(functions)),
 		Workload:		make([]int, len(functions)),
@@ -229,14 +460,21 @@ func prepareFunctionInfo(functions []string) FunctionInformation {
 	for i, functionName := range functions {
 		f, err := function.GetFunction(functionName)
 		if !err {
-			log.Printf("Error retrieving function %s: %v", functionName, err)
+			Logger.Warn("Error retrieving function", "function", functionName)
 			continue
 		}
 
 		functionInfo.MemoryMB[i] = int(f.MemoryMB)
 		functionInfo.Workload[i] = int(f.Workload / 1e6)
 		functionInfo.Deadline[i] = int(f.Deadline)
-		functionInfo.Invocations[i] = int(f.Invocations)
+
+		// Prefer the measured invocation count from this epoch's stats scrape
+		// over the stale, monotonically-increasing f.Invocations counter.
+		if usage, ok := clusterStats[functionName]; ok {
+			functionInfo.Invocations[i] = int(usage.Invocations)
+		} else {
+			functionInfo.Invocations[i] = int(f.Invocations)
+		}
 	}
 
 	return functionInfo
@@ -250,7 +488,7 @@ func computeFunctionsAllocation(results SolverResults, functions []string, nodeI
 			if floatVal, ok := instances[i].(float64); ok {
 				ipInstancesMap[nodeIp[key]] = int(floatVal)
 			} else {
-				log.Printf("Expected float64 but found %T at index %d for nodeID %d", instances[i], i, key)
+				Logger.Warn("Unexpected instances type", "type", fmt.Sprintf("%T", instances[i]), "functionIndex", i, "nodeID", key)
 			}
 		}
 
@@ -273,34 +511,21 @@ func computeFunctionsAllocation(results SolverResults, functions []string, nodeI
 	return allocation, nil
 }
 
-// Helper function to allocate and initialize C memory
-func allocateAndInitialize(data []int) *C.int {
-	size := len(data)
-	cArray := C.allocateMemory(C.int(size))
-	for i := 0; i < size; i++ {
-		cElement := (*C.int)(unsafe.Pointer(uintptr(unsafe.Pointer(cArray)) + uintptr(i)*unsafe.Sizeof(*cArray)))
-		*cElement = C.int(data[i])
-	}
-	return cArray
-}
-
 func initNodeResources() error {
 	// Initialize node resources information
 	cpuInfo, err := cpu.Info()
 	if err != nil {
-		log.Fatal(err)
-		return err
+		return fmt.Errorf("Error reading cpu info: %v", err)
 	}
 
 	vMemInfo, err := mem.VirtualMemory()
 	if err != nil {
-		log.Fatal(err)
-		return err
+		return fmt.Errorf("Error reading virtual memory info: %v", err)
 	}
 
 	node.Resources.ComputationalCapacity = cpuInfo[0].Mhz * float64(len(cpuInfo))
 	node.Resources.MaximumCapacity = cpuInfo[0].Mhz
-	node.Resources.IPC = 1 // TODO
+	node.Resources.IPC = estimateNodeIPC(utils.GetIpAddress().String()) // defaults to 1 until an epoch has measured usage
 	node.Resources.PowerConsumption = 400 // TODO
 	node.Resources.TotalMemoryMB = int64(vMemInfo.Total / 1e6)
 
@@ -319,58 +544,38 @@ func GetAllocation() FunctionsAllocation {
     return Allocation
 }
 
-func saveAllocationToEtcd(allocation FunctionsAllocation) error {
-	etcdClient, err := utils.GetEtcdClient()
-	if err != nil {
-		log.Fatal(err)
-		return err
-	}
-
-	payload, err := json.Marshal(allocation)
-	if err != nil {
-		return fmt.Errorf("Could not marshal allocation: %v", err)
-	}
-
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-	resp, err := etcdClient.Grant(ctx, 60) // TODO: lease time
-	if err != nil {
-		log.Fatal(err)
-		return err
-	}
+// retrySaveAllocationBaseDelay is the initial backoff between retries of a
+// failed currentAllocationStore().Save; it doubles on every further failure, capped at
+// retrySaveAllocationMaxDelay.
+const (
+	retrySaveAllocationBaseDelay = 1 * time.Second
+	retrySaveAllocationMaxDelay  = 30 * time.Second
+)
 
-	_, err = etcdClient.Put(ctx, "allocation", string(payload), clientv3.WithLease(resp.ID))
-	if err != nil {
-		log.Fatal(err)
-		return err
-	}
+// retrySaveAllocation keeps retrying a failed currentAllocationStore().Save in the
+// background, with exponential backoff, until it succeeds or a newer
+// allocation supersedes it.
+func retrySaveAllocation(payload []byte) {
+	delay := retrySaveAllocationBaseDelay
+	for {
+		time.Sleep(delay)
+
+		if latest, err := lastAllocationPayload.Load(); err == nil && string(latest) != string(payload) {
+			// A newer allocation has since been computed and saved (or is
+			// being retried on its own); this attempt is stale.
+			return
+		}
 
-	return nil
-}
+		if err := currentAllocationStore().Save(payload); err == nil {
+			Logger.Info("allocation_published", "retried", true)
+			return
+		} else {
+			Logger.Warn("Retried allocation save failed, will retry", "err", err)
+		}
 
-func getAllocationFromEtcd() (FunctionsAllocation, error) {
-	etcdClient, err := utils.GetEtcdClient()
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		delay *= 2
+		if delay > retrySaveAllocationMaxDelay {
+			delay = retrySaveAllocationMaxDelay
+		}
 	}
-
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-    defer cancel()
-
-    resp, err := etcdClient.Get(ctx, "allocation")
-    if err != nil {
-        return nil, fmt.Errorf("Failed to get allocation from etcd: %v", err)
-    }
-
-    if len(resp.Kvs) == 0 {
-        return nil, fmt.Errorf("No data found for key 'allocation'")
-    }
-
-    var allocation FunctionsAllocation
-    err = json.Unmarshal(resp.Kvs[0].Value, &allocation)
-    if err != nil {
-        return nil, fmt.Errorf("Failed to unmarshal allocation: %v", err)
-    }
-
-    return allocation, nil
 }
@@ -0,0 +1,226 @@
+package solver
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grussorusso/serverledge/internal/registration"
+	"github.com/grussorusso/serverledge/utils"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"golang.org/x/net/context"
+)
+
+// StatsEndpoint is the path, under Serverledge's existing HTTP API, at which
+// RegisterStatsHandler exposes StatsHandler.
+const StatsEndpoint = "/alloc_stats"
+
+// RegisterStatsHandler exposes StatsHandler on mux, at StatsEndpoint. The
+// api package's server setup must call this on the same *http.ServeMux (or
+// equivalent router) it serves Serverledge's other handlers from — StatsHandler
+// is not reachable on a production node until something does, since
+// self-registering on http.DefaultServeMux here would silently 404 on any
+// server built around its own router instead of http.ListenAndServe(addr, nil).
+func RegisterStatsHandler(mux *http.ServeMux) {
+	mux.HandleFunc(StatsEndpoint, StatsHandler)
+}
+
+// TaskResourceUsage is a single function's measured resource consumption on
+// a node since the last scrape, mirroring Nomad's
+// client/allocation/{id}/stats endpoint.
+type TaskResourceUsage struct {
+	CPUSeconds     float64 `json:"cpuSeconds"`
+	MemoryRSSMB    int64   `json:"memoryRssMb"`
+	Invocations    int64   `json:"invocations"`
+	DeadlineMisses int64   `json:"deadlineMisses"`
+}
+
+// AllocStatsReporter accumulates per-function resource usage on this node
+// between solver epochs. Usage is recorded through RecordInvocation on this
+// package's statsReporter; statsReporter is unexported, so only code in this
+// package can record into it. The solver node scrapes Snapshot via
+// StatsHandler once per epoch.
+type AllocStatsReporter struct {
+	mu    sync.Mutex
+	stats map[string]*TaskResourceUsage
+}
+
+func newAllocStatsReporter() *AllocStatsReporter {
+	return &AllocStatsReporter{stats: make(map[string]*TaskResourceUsage)}
+}
+
+var statsReporter = newAllocStatsReporter()
+
+// RecordInvocation accounts for a single completed invocation of functionName.
+func (r *AllocStatsReporter) RecordInvocation(functionName string, cpuSeconds float64, memoryRSSMB int64, missedDeadline bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage, ok := r.stats[functionName]
+	if !ok {
+		usage = &TaskResourceUsage{}
+		r.stats[functionName] = usage
+	}
+
+	usage.CPUSeconds += cpuSeconds
+	usage.MemoryRSSMB = memoryRSSMB
+	usage.Invocations++
+	if missedDeadline {
+		usage.DeadlineMisses++
+	}
+}
+
+// Snapshot returns the usage accumulated since the last Snapshot call and
+// resets the counters, so every epoch's scrape reflects only that epoch.
+func (r *AllocStatsReporter) Snapshot() map[string]TaskResourceUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[string]TaskResourceUsage, len(r.stats))
+	for name, usage := range r.stats {
+		snapshot[name] = *usage
+	}
+	r.stats = make(map[string]*TaskResourceUsage)
+
+	return snapshot
+}
+
+// StatsHandler serves this node's per-function resource usage since the last
+// scrape. It is served under StatsEndpoint only on a mux that
+// RegisterStatsHandler has been called on.
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	snapshot := statsReporter.Snapshot()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var statsClient = &http.Client{Timeout: 5 * time.Second}
+
+// scrapeClusterStats collects per-function resource usage from every peer,
+// plus this node's own AllocStatsReporter, at the start of an epoch. It
+// returns both the usage broken down per node (for the IPC/workload EWMA)
+// and the cluster-wide aggregate (for prepareFunctionInfo).
+func scrapeClusterStats(serversMap map[string]*registration.StatusInformation) (map[string]map[string]TaskResourceUsage, map[string]TaskResourceUsage) {
+	perNode := make(map[string]map[string]TaskResourceUsage)
+	aggregate := make(map[string]TaskResourceUsage)
+
+	addNodeUsage := func(nodeIp string, usage map[string]TaskResourceUsage) {
+		perNode[nodeIp] = usage
+		for functionName, u := range usage {
+			agg := aggregate[functionName]
+			agg.CPUSeconds += u.CPUSeconds
+			agg.MemoryRSSMB += u.MemoryRSSMB
+			agg.Invocations += u.Invocations
+			agg.DeadlineMisses += u.DeadlineMisses
+			aggregate[functionName] = agg
+		}
+	}
+
+	addNodeUsage(utils.GetIpAddress().String(), statsReporter.Snapshot())
+
+	for _, server := range serversMap {
+		resp, err := statsClient.Get(server.Url + StatsEndpoint)
+		if err != nil {
+			Logger.Warn("Error scraping stats from peer", "url", server.Url, "err", err)
+			continue
+		}
+
+		var usage map[string]TaskResourceUsage
+		err = json.NewDecoder(resp.Body).Decode(&usage)
+		resp.Body.Close()
+		if err != nil {
+			Logger.Warn("Error decoding stats from peer", "url", server.Url, "err", err)
+			continue
+		}
+
+		addNodeUsage(server.Url[7:len(server.Url)-5], usage)
+	}
+
+	return perNode, aggregate
+}
+
+// ewmaAlpha weighs how much a freshly measured sample moves the persisted
+// rolling estimate; lower values smooth over more epochs.
+const ewmaAlpha = 0.3
+
+const ipcStatsPrefix = "solver/ipc/"
+
+// ipcSample is the rolling EWMA of a (node, function) pair's measured IPC
+// and workload, persisted in etcd so estimates survive solver restarts and
+// failovers.
+type ipcSample struct {
+	IPC      float64 `json:"ipc"`
+	Workload float64 `json:"workload"`
+}
+
+func ipcStatsKey(nodeIp string, functionName string) string {
+	return ipcStatsPrefix + nodeIp + "/" + functionName
+}
+
+// updateIpcEwma folds a freshly measured (IPC, workload) sample for a
+// (node, function) pair into its persisted rolling average.
+func updateIpcEwma(nodeIp string, functionName string, measuredIpc float64, measuredWorkload float64) {
+	etcdClient, err := utils.GetEtcdClient()
+	if err != nil {
+		Logger.Warn("Error getting etcd client for IPC EWMA update", "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := ipcStatsKey(nodeIp, functionName)
+	sample := ipcSample{IPC: measuredIpc, Workload: measuredWorkload}
+
+	if resp, err := etcdClient.Get(ctx, key); err == nil && len(resp.Kvs) > 0 {
+		var previous ipcSample
+		if err := json.Unmarshal(resp.Kvs[0].Value, &previous); err == nil {
+			sample.IPC = ewmaAlpha*measuredIpc + (1-ewmaAlpha)*previous.IPC
+			sample.Workload = ewmaAlpha*measuredWorkload + (1-ewmaAlpha)*previous.Workload
+		}
+	}
+
+	payload, err := json.Marshal(sample)
+	if err != nil {
+		Logger.Warn("Error marshalling IPC EWMA sample", "err", err)
+		return
+	}
+
+	if _, err := etcdClient.Put(ctx, key, string(payload)); err != nil {
+		Logger.Warn("Error persisting IPC EWMA sample", "key", key, "err", err)
+	}
+}
+
+// estimateNodeIPC averages the persisted per-function IPC EWMA for nodeIp,
+// falling back to 1 when no samples have been recorded yet (e.g. on a node's
+// first epoch).
+func estimateNodeIPC(nodeIp string) float64 {
+	etcdClient, err := utils.GetEtcdClient()
+	if err != nil {
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := etcdClient.Get(ctx, ipcStatsKey(nodeIp, ""), clientv3.WithPrefix())
+	if err != nil || len(resp.Kvs) == 0 {
+		return 1
+	}
+
+	var total float64
+	for _, kv := range resp.Kvs {
+		var sample ipcSample
+		if err := json.Unmarshal(kv.Value, &sample); err != nil {
+			continue
+		}
+		total += sample.IPC
+	}
+
+	return total / float64(len(resp.Kvs))
+}
@@ -0,0 +1,59 @@
+package solver
+
+import "testing"
+
+func TestAllocStatsReporterRecordInvocationAccumulates(t *testing.T) {
+	reporter := newAllocStatsReporter()
+
+	reporter.RecordInvocation("fn", 1.5, 100, false)
+	reporter.RecordInvocation("fn", 2.5, 150, true)
+
+	snapshot := reporter.Snapshot()
+	usage, ok := snapshot["fn"]
+	if !ok {
+		t.Fatalf("expected usage recorded for fn, got none")
+	}
+
+	if usage.CPUSeconds != 4 {
+		t.Errorf("expected CPUSeconds to accumulate to 4, got %v", usage.CPUSeconds)
+	}
+	if usage.MemoryRSSMB != 150 {
+		t.Errorf("expected MemoryRSSMB to reflect the latest sample (150), got %v", usage.MemoryRSSMB)
+	}
+	if usage.Invocations != 2 {
+		t.Errorf("expected Invocations to count 2 calls, got %v", usage.Invocations)
+	}
+	if usage.DeadlineMisses != 1 {
+		t.Errorf("expected DeadlineMisses to count the single missed deadline, got %v", usage.DeadlineMisses)
+	}
+}
+
+func TestAllocStatsReporterRecordInvocationMultipleFunctions(t *testing.T) {
+	reporter := newAllocStatsReporter()
+
+	reporter.RecordInvocation("fn1", 1, 10, false)
+	reporter.RecordInvocation("fn2", 2, 20, false)
+
+	snapshot := reporter.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("expected usage for 2 distinct functions, got %v", snapshot)
+	}
+	if snapshot["fn1"].Invocations != 1 || snapshot["fn2"].Invocations != 1 {
+		t.Errorf("expected each function to be accounted separately, got %+v", snapshot)
+	}
+}
+
+func TestAllocStatsReporterSnapshotResets(t *testing.T) {
+	reporter := newAllocStatsReporter()
+	reporter.RecordInvocation("fn", 1, 10, false)
+
+	first := reporter.Snapshot()
+	if len(first) != 1 {
+		t.Fatalf("expected the first snapshot to contain the recorded usage, got %v", first)
+	}
+
+	second := reporter.Snapshot()
+	if len(second) != 0 {
+		t.Errorf("expected Snapshot to reset accumulated usage, got %v", second)
+	}
+}
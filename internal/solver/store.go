@@ -0,0 +1,147 @@
+package solver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grussorusso/serverledge/utils"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"golang.org/x/net/context"
+)
+
+// allocationLeaseSeconds is the TTL of the etcd lease backing the persisted
+// allocation; it mirrors the lease previously hard-coded in
+// saveAllocationToEtcd.
+const allocationLeaseSeconds = 60
+
+// Store persists the raw, already-marshalled allocation payload so solve()
+// can survive a restart without waiting for the next epoch, and so a node
+// without etcd access can still keep track of the last allocation it computed.
+type Store interface {
+	Save([]byte) error
+	Load() ([]byte, error)
+}
+
+// EtcdStore persists the allocation under the "allocation" key, leased so it
+// disappears if no leader renews it.
+type EtcdStore struct {
+	client *clientv3.Client
+}
+
+func NewEtcdStore(client *clientv3.Client) *EtcdStore {
+	return &EtcdStore{client: client}
+}
+
+func (s *EtcdStore) Save(payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := s.client.Grant(ctx, allocationLeaseSeconds)
+	if err != nil {
+		return fmt.Errorf("Could not grant etcd lease: %v", err)
+	}
+
+	if _, err := s.client.Put(ctx, "allocation", string(payload), clientv3.WithLease(resp.ID)); err != nil {
+		return fmt.Errorf("Could not save allocation to etcd: %v", err)
+	}
+
+	return nil
+}
+
+func (s *EtcdStore) Load() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, "allocation")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get allocation from etcd: %v", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("No data found for key 'allocation'")
+	}
+
+	return resp.Kvs[0].Value, nil
+}
+
+// InMemStore is used when etcd is unconfigured (empty -endpoints) or
+// temporarily unreachable, so a node is never left without a last-known
+// allocation just because etcd is down.
+type InMemStore struct {
+	mu      sync.RWMutex
+	payload []byte
+}
+
+func NewInMemStore() *InMemStore {
+	return &InMemStore{}
+}
+
+func (s *InMemStore) Save(payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payload = payload
+	return nil
+}
+
+func (s *InMemStore) Load() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.payload == nil {
+		return nil, fmt.Errorf("No allocation stored in memory")
+	}
+	return s.payload, nil
+}
+
+// newAllocationStore picks an EtcdStore when etcd is reachable, falling back
+// to an InMemStore otherwise.
+func newAllocationStore() Store {
+	etcdClient, err := utils.GetEtcdClient()
+	if err != nil {
+		Logger.Warn("Etcd unavailable, falling back to in-memory allocation store", "err", err)
+		return NewInMemStore()
+	}
+
+	return NewEtcdStore(etcdClient)
+}
+
+// allocationStoreMu guards currentStore, which currentAllocationStore may
+// replace concurrently with solve()'s own reads of it.
+var (
+	allocationStoreMu sync.RWMutex
+	currentStore      Store
+)
+
+// setAllocationStore installs store as the allocation store subsequently
+// returned by currentAllocationStore.
+func setAllocationStore(store Store) {
+	allocationStoreMu.Lock()
+	defer allocationStoreMu.Unlock()
+	currentStore = store
+}
+
+// currentAllocationStore returns the allocation store to use right now. If
+// the store currently in use is only the in-memory fallback, it re-probes
+// etcd on every call and upgrades to a fresh EtcdStore as soon as one becomes
+// reachable again — otherwise a node that merely started up (or resynced)
+// during a transient etcd outage would stay pinned to InMemStore, which
+// nothing else ever writes into, for the rest of the process's life.
+func currentAllocationStore() Store {
+	allocationStoreMu.RLock()
+	store := currentStore
+	allocationStoreMu.RUnlock()
+
+	if _, isFallback := store.(*InMemStore); !isFallback {
+		return store
+	}
+
+	etcdClient, err := utils.GetEtcdClient()
+	if err != nil {
+		return store
+	}
+
+	upgraded := NewEtcdStore(etcdClient)
+	setAllocationStore(upgraded)
+	Logger.Info("Etcd reachable again, upgrading from in-memory allocation store")
+	return upgraded
+}
@@ -0,0 +1,49 @@
+package solver
+
+import "testing"
+
+func TestInMemStoreLoadBeforeSave(t *testing.T) {
+	store := NewInMemStore()
+
+	if _, err := store.Load(); err == nil {
+		t.Fatalf("expected an error loading from an empty store, got nil")
+	}
+}
+
+func TestInMemStoreSaveLoadRoundTrip(t *testing.T) {
+	store := NewInMemStore()
+	payload := []byte(`{"fn":{"capacity":1,"instances":{"10.0.0.1":2}}}`)
+
+	if err := store.Save(payload); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	if string(loaded) != string(payload) {
+		t.Errorf("expected Load to return the saved payload %q, got %q", payload, loaded)
+	}
+}
+
+func TestInMemStoreSaveOverwrites(t *testing.T) {
+	store := NewInMemStore()
+
+	if err := store.Save([]byte("first")); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	if err := store.Save([]byte("second")); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	if string(loaded) != "second" {
+		t.Errorf("expected the latest Save to win, got %q", loaded)
+	}
+}